@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/sync/singleflight"
+)
+
+// ClientCredentialsConfig describes an OAuth2 client-credentials grant used
+// to mint bearer tokens for TokenSourceTransport.
+type ClientCredentialsConfig struct {
+	Issuer       string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
+func (c ClientCredentialsConfig) cacheKey() string {
+	return strings.Join([]string{c.Issuer, c.ClientID, strings.Join(c.Scopes, ",")}, "|")
+}
+
+func (c ClientCredentialsConfig) oauth2Config() *clientcredentials.Config {
+	return &clientcredentials.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		TokenURL:     c.TokenURL,
+		Scopes:       c.Scopes,
+	}
+}
+
+var (
+	tokenCacheMu sync.Mutex
+	tokenCache   = map[string]*oauth2.Token{}
+	tokenGroup   singleflight.Group
+)
+
+// TokenSourceTransport is an http.RoundTripper that attaches a bearer token
+// to every outgoing request, fetching it transparently via an OAuth2
+// client-credentials flow. Tokens are cached process-wide by (issuer,
+// client_id, scopes), so many HttpRequest values can share one cached
+// token, and concurrent refreshes for the same key are coalesced with
+// singleflight so a flood of 401s doesn't trigger duplicate token
+// requests.
+type TokenSourceTransport struct {
+	Next   http.RoundTripper
+	Config ClientCredentialsConfig
+}
+
+func (t *TokenSourceTransport) base() http.RoundTripper {
+	if t.Next != nil {
+		return t.Next
+	}
+	return http.DefaultTransport
+}
+
+func (t *TokenSourceTransport) key() string {
+	return t.Config.cacheKey()
+}
+
+func (t *TokenSourceTransport) evict() {
+	tokenCacheMu.Lock()
+	delete(tokenCache, t.key())
+	tokenCacheMu.Unlock()
+}
+
+func (t *TokenSourceTransport) token(ctx context.Context) (string, error) {
+	key := t.key()
+
+	tokenCacheMu.Lock()
+	cached, ok := tokenCache[key]
+	tokenCacheMu.Unlock()
+	if ok && cached.Valid() {
+		return cached.AccessToken, nil
+	}
+
+	result, err, _ := tokenGroup.Do(key, func() (interface{}, error) {
+		tok, err := t.Config.oauth2Config().Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		tokenCacheMu.Lock()
+		tokenCache[key] = tok
+		tokenCacheMu.Unlock()
+
+		return tok, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return result.(*oauth2.Token).AccessToken, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *TokenSourceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.token(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("transport: fetching bearer token: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	res, err := t.base().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// A 401 despite a "valid" cached token usually means the issuer revoked
+	// it early; evict so the next request fetches a fresh one.
+	if res.StatusCode == http.StatusUnauthorized {
+		t.evict()
+	}
+
+	return res, nil
+}
@@ -0,0 +1,231 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitBreakerState is the state of a per-host CircuitBreaker.
+type CircuitBreakerState int
+
+const (
+	CircuitClosed CircuitBreakerState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// ErrCircuitOpen is returned when a request is short-circuited because its
+// host's circuit breaker is open.
+var ErrCircuitOpen = errors.New("transport: circuit breaker open")
+
+// CircuitBreakerConfig configures WithCircuitBreaker.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the minimum number of requests observed in the
+	// sliding window before the failure ratio is evaluated. Defaults to 10.
+	FailureThreshold int
+
+	// FailureRatio opens the circuit once at least FailureThreshold
+	// requests have been observed and this fraction of them failed.
+	// Defaults to 0.5.
+	FailureRatio float64
+
+	// Window is the sliding window size, in number of recent requests,
+	// used to compute the failure ratio. Defaults to 20.
+	Window int
+
+	// Cooldown is how long the circuit stays open before moving to
+	// half-open and letting a single trial request through. Defaults to
+	// 30s.
+	Cooldown time.Duration
+
+	// IsFailure classifies a round trip's outcome as a failure. Defaults
+	// to treating transport errors and 5xx responses as failures.
+	IsFailure func(res *http.Response, err error) bool
+
+	// OnStateChange, if set, is called whenever a host's breaker
+	// transitions state, e.g. to feed metrics or tracing.
+	OnStateChange func(host string, from, to CircuitBreakerState)
+}
+
+func (c CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 10
+	}
+	if c.FailureRatio <= 0 {
+		c.FailureRatio = 0.5
+	}
+	if c.Window <= 0 {
+		c.Window = 20
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = 30 * time.Second
+	}
+	if c.IsFailure == nil {
+		c.IsFailure = defaultIsFailure
+	}
+	return c
+}
+
+func defaultIsFailure(res *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return res.StatusCode >= 500
+}
+
+// circuitBreaker is a sliding-window circuit breaker for a single host.
+type circuitBreaker struct {
+	cfg CircuitBreakerConfig
+
+	mu            sync.Mutex
+	state         CircuitBreakerState
+	outcomes      []bool // true = failure, oldest first
+	openedAt      time.Time
+	trialInFlight bool
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker {
+	return &circuitBreaker{cfg: cfg}
+}
+
+// allow reports whether a request to host may proceed, moving an open
+// circuit to half-open once the cooldown elapses and admitting exactly one
+// trial request while half-open.
+func (b *circuitBreaker) allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.cfg.Cooldown {
+		b.setState(host, CircuitHalfOpen)
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if b.trialInFlight {
+			return false
+		}
+		b.trialInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// recordResult feeds the outcome of an admitted request back into the
+// breaker, closing a successful half-open trial, re-opening a failed one,
+// or evaluating the sliding-window failure ratio while closed.
+func (b *circuitBreaker) recordResult(host string, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen {
+		b.trialInFlight = false
+		if failed {
+			b.setState(host, CircuitOpen)
+			b.openedAt = time.Now()
+		} else {
+			b.setState(host, CircuitClosed)
+		}
+		b.outcomes = nil
+		return
+	}
+
+	b.outcomes = append(b.outcomes, failed)
+	if len(b.outcomes) > b.cfg.Window {
+		b.outcomes = b.outcomes[len(b.outcomes)-b.cfg.Window:]
+	}
+
+	if len(b.outcomes) < b.cfg.FailureThreshold {
+		return
+	}
+
+	failures := 0
+	for _, f := range b.outcomes {
+		if f {
+			failures++
+		}
+	}
+
+	if float64(failures)/float64(len(b.outcomes)) >= b.cfg.FailureRatio {
+		b.setState(host, CircuitOpen)
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *circuitBreaker) setState(host string, to CircuitBreakerState) {
+	if b.state == to {
+		return
+	}
+	from := b.state
+	b.state = to
+	if b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(host, from, to)
+	}
+}
+
+type circuitBreakerTransport struct {
+	next http.RoundTripper
+	cfg  CircuitBreakerConfig
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func (t *circuitBreakerTransport) base() http.RoundTripper {
+	if t.next != nil {
+		return t.next
+	}
+	return http.DefaultTransport
+}
+
+func (t *circuitBreakerTransport) breakerFor(host string) *circuitBreaker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.breakers[host]
+	if !ok {
+		b = newCircuitBreaker(t.cfg)
+		t.breakers[host] = b
+	}
+	return b
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	breaker := t.breakerFor(host)
+
+	if !breaker.allow(host) {
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+	}
+
+	res, err := t.base().RoundTrip(req)
+	breaker.recordResult(host, t.cfg.IsFailure(res, err))
+	return res, err
+}
+
+// WithCircuitBreaker returns a Middleware that trips a per-host circuit
+// breaker on repeated failures (5xx responses or connection errors by
+// default), short-circuiting further calls to that host with
+// ErrCircuitOpen for cfg.Cooldown.
+func WithCircuitBreaker(cfg CircuitBreakerConfig) Middleware {
+	cfg = cfg.withDefaults()
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &circuitBreakerTransport{next: next, cfg: cfg, breakers: make(map[string]*circuitBreaker)}
+	}
+}
@@ -0,0 +1,143 @@
+package transport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTripsAfterFailureRatio(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 4,
+		FailureRatio:     0.5,
+		Window:           4,
+		Cooldown:         time.Hour,
+	})
+
+	for i := 0; i < 3; i++ {
+		if !b.allow("example.com") {
+			t.Fatalf("allow() = false before the breaker has seen enough requests to trip")
+		}
+		b.recordResult("example.com", true)
+	}
+
+	if b.state != CircuitClosed {
+		t.Fatalf("state = %v, want %v before FailureThreshold requests are observed", b.state, CircuitClosed)
+	}
+
+	if !b.allow("example.com") {
+		t.Fatalf("allow() = false before the 4th request")
+	}
+	b.recordResult("example.com", true)
+
+	if b.state != CircuitOpen {
+		t.Fatalf("state = %v, want %v after 4/4 failures reach the ratio", b.state, CircuitOpen)
+	}
+	if b.allow("example.com") {
+		t.Fatalf("allow() = true while the breaker is open")
+	}
+}
+
+func TestCircuitBreakerStaysClosedBelowRatio(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 4,
+		FailureRatio:     0.5,
+		Window:           4,
+		Cooldown:         time.Hour,
+	})
+
+	outcomes := []bool{true, false, false, false} // 1/4 failures, below the 0.5 ratio
+	for _, failed := range outcomes {
+		if !b.allow("example.com") {
+			t.Fatalf("allow() = false, want true")
+		}
+		b.recordResult("example.com", failed)
+	}
+
+	if b.state != CircuitClosed {
+		t.Fatalf("state = %v, want %v when the failure ratio stays below threshold", b.state, CircuitClosed)
+	}
+}
+
+func TestCircuitBreakerHalfOpenTrialSuccessCloses(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		FailureRatio:     0.5,
+		Window:           1,
+		Cooldown:         10 * time.Millisecond,
+	})
+
+	b.allow("example.com")
+	b.recordResult("example.com", true)
+	if b.state != CircuitOpen {
+		t.Fatalf("state = %v, want %v after the first failure trips the breaker", b.state, CircuitOpen)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if !b.allow("example.com") {
+		t.Fatalf("allow() = false, want true for the half-open trial request after cooldown")
+	}
+	if b.state != CircuitHalfOpen {
+		t.Fatalf("state = %v, want %v once cooldown elapses", b.state, CircuitHalfOpen)
+	}
+	if b.allow("example.com") {
+		t.Fatalf("allow() = true for a second concurrent half-open trial, want false")
+	}
+
+	b.recordResult("example.com", false)
+	if b.state != CircuitClosed {
+		t.Fatalf("state = %v, want %v after a successful half-open trial", b.state, CircuitClosed)
+	}
+	if !b.allow("example.com") {
+		t.Fatalf("allow() = false, want true once the breaker is closed again")
+	}
+}
+
+func TestCircuitBreakerHalfOpenTrialFailureReopens(t *testing.T) {
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		FailureRatio:     0.5,
+		Window:           1,
+		Cooldown:         10 * time.Millisecond,
+	})
+
+	b.allow("example.com")
+	b.recordResult("example.com", true)
+	time.Sleep(20 * time.Millisecond)
+
+	if !b.allow("example.com") {
+		t.Fatalf("allow() = false, want true for the half-open trial request")
+	}
+	b.recordResult("example.com", true)
+
+	if b.state != CircuitOpen {
+		t.Fatalf("state = %v, want %v after a failed half-open trial", b.state, CircuitOpen)
+	}
+	if b.allow("example.com") {
+		t.Fatalf("allow() = true immediately after the trial failure re-opened the breaker, want false")
+	}
+}
+
+func TestCircuitBreakerStateChangeHook(t *testing.T) {
+	var transitions []CircuitBreakerState
+
+	b := newCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 1,
+		FailureRatio:     0.5,
+		Window:           1,
+		Cooldown:         time.Hour,
+		OnStateChange: func(host string, from, to CircuitBreakerState) {
+			if host != "example.com" {
+				t.Errorf("OnStateChange host = %q, want %q", host, "example.com")
+			}
+			transitions = append(transitions, to)
+		},
+	})
+
+	b.allow("example.com")
+	b.recordResult("example.com", true)
+
+	if len(transitions) != 1 || transitions[0] != CircuitOpen {
+		t.Errorf("transitions = %v, want [%v]", transitions, CircuitOpen)
+	}
+}
@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+
+	"github.com/bytedance/sonic"
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec marshals and unmarshals request/response bodies for a specific
+// content type. RequestAutoBodyParser negotiates one from the response's
+// Content-Type header; HttpRequest.Codec negotiates one for the request
+// body.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	ContentType() string
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return sonic.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return sonic.Unmarshal(data, v) }
+func (jsonCodec) ContentType() string                        { return "application/json" }
+
+type xmlCodec struct{}
+
+func (xmlCodec) Marshal(v interface{}) ([]byte, error)      { return xml.Marshal(v) }
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error { return xml.Unmarshal(data, v) }
+func (xmlCodec) ContentType() string                        { return "application/xml" }
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("transport: protobuf codec requires a proto.Message, got %T", v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("transport: protobuf codec requires a proto.Message, got %T", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func (protobufCodec) ContentType() string { return "application/protobuf" }
+
+// rawCodec passes bytes through unchanged. It's used for text/plain and as
+// the fallback for any content type with no registered codec.
+type rawCodec struct{ contentType string }
+
+func (c rawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch data := v.(type) {
+	case []byte:
+		return data, nil
+	case string:
+		return []byte(data), nil
+	default:
+		return nil, fmt.Errorf("transport: raw codec requires []byte or string, got %T", v)
+	}
+}
+
+func (c rawCodec) Unmarshal(data []byte, v interface{}) error {
+	switch dst := v.(type) {
+	case *[]byte:
+		*dst = data
+		return nil
+	case *string:
+		*dst = string(data)
+		return nil
+	default:
+		return fmt.Errorf("transport: raw codec requires *[]byte or *string, got %T", v)
+	}
+}
+
+func (c rawCodec) ContentType() string { return c.contentType }
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[string]Codec{
+		"application/json":       jsonCodec{},
+		"application/xml":        xmlCodec{},
+		"text/xml":               xmlCodec{},
+		"application/protobuf":   protobufCodec{},
+		"application/x-protobuf": protobufCodec{},
+		"text/plain":             rawCodec{contentType: "text/plain"},
+	}
+	// fallbackCodec is used when the Content-Type is absent or doesn't match
+	// a registered codec. It passes raw bytes through, so decoding into
+	// anything other than *[]byte or *string fails.
+	fallbackCodec Codec = rawCodec{contentType: "application/octet-stream"}
+)
+
+// RegisterCodec registers (or overrides) the Codec used for mimeType.
+func RegisterCodec(mimeType string, codec Codec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[mimeType] = codec
+}
+
+// codecFor returns the Codec registered for contentType, matching only the
+// MIME type itself (parameters like charset are ignored). It falls back to
+// the raw-bytes codec when contentType is empty or nothing is registered
+// for it.
+func codecFor(contentType string) Codec {
+	mimeType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mimeType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	}
+
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+
+	if codec, ok := codecs[mimeType]; ok {
+		return codec
+	}
+	return fallbackCodec
+}
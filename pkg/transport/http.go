@@ -3,7 +3,6 @@ package transport
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -12,7 +11,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/bytedance/sonic"
+	"github.com/google/uuid"
 	"github.com/kondohiroki/go-grpc-boilerplate/internal/logger"
 	"go.uber.org/zap"
 )
@@ -32,9 +31,73 @@ type HttpRequest struct {
 	Query      map[string]string
 	Params     map[string]string
 
+	// BodyReader, if set, is used instead of Body for the request payload.
+	// It is read once; since it can't be rewound, it's skipped on retries,
+	// redirects, and 401 token renewals. Prefer BodyProvider if the
+	// request may need to be resent.
+	BodyReader io.Reader
+
+	// BodyProvider, if set, is called to open a fresh request body for
+	// every attempt (initial send, retries, redirects, and 401 token
+	// renewals), taking precedence over BodyReader and Body. Use this for
+	// large or streamed uploads that shouldn't be buffered in memory.
+	BodyProvider func() (io.ReadCloser, error)
+
+	// BodyObject, if set and BodyProvider/BodyReader are nil, is marshaled
+	// with Codec (or the JSON codec, by default) to produce the request
+	// body, and its Content-Type is set automatically unless Headers
+	// already has one.
+	BodyObject interface{}
+
+	// Codec marshals BodyObject for the request. Defaults to the JSON
+	// codec when BodyObject is set and Codec is nil.
+	Codec Codec
+
+	// Retry configures retrying on transient network errors and retryable
+	// status codes (429/502/503/504 by default). Nil disables retrying.
+	Retry *RetryPolicy
+
+	// Redirect configures how 3xx responses are followed. Nil uses
+	// RedirectPolicy's defaults (follow up to 10 redirects, any host).
+	Redirect *RedirectPolicy
+
 	onRenewBearer func(context.Context) (string, error)
 }
 
+// openBody returns the io.Reader to send for the next attempt. BodyProvider
+// is invoked fresh every time it's called (so retries replay a new stream);
+// BodyReader is returned as-is and can only be used once; otherwise buf is
+// refilled from Body.
+func (req *HttpRequest) openBody(buf *bytes.Buffer) (io.Reader, error) {
+	if req.BodyProvider != nil {
+		return req.BodyProvider()
+	}
+	if req.BodyReader != nil {
+		return req.BodyReader, nil
+	}
+	if req.BodyObject != nil {
+		data, err := req.requestCodec().Marshal(req.BodyObject)
+		if err != nil {
+			return nil, fmt.Errorf("transport: marshaling request body: %w", err)
+		}
+		buf.Reset()
+		buf.Write(data)
+		return buf, nil
+	}
+	buf.Reset()
+	buf.ReadFrom(bytes.NewReader(req.Body))
+	return buf, nil
+}
+
+// requestCodec returns the Codec used to marshal BodyObject, defaulting to
+// JSON.
+func (req *HttpRequest) requestCodec() Codec {
+	if req.Codec != nil {
+		return req.Codec
+	}
+	return jsonCodec{}
+}
+
 // WithBearer sets bearer token in authorization header. The renewerFunc can be provided
 // if you want to renew a token when got 401 response where the returned string is a newly token.
 func (req *HttpRequest) WithBearer(token string, renewerFunc ...func(context.Context) (string, error)) {
@@ -48,7 +111,11 @@ func (req *HttpRequest) WithBearer(token string, renewerFunc ...func(context.Con
 	}
 }
 
-func NewHTTPClient() *http.Client {
+// NewHTTPClient builds the standard *http.Client used across the codebase.
+// Optional middlewares (logging, metrics, retry, auth, ...) are layered
+// around the base transport outermost-first; see Chain and
+// TokenSourceTransport.
+func NewHTTPClient(middlewares ...Middleware) *http.Client {
 	transport := &http.Transport{
 		DialContext: (&net.Dialer{
 			Timeout:   30 * time.Second,
@@ -63,7 +130,7 @@ func NewHTTPClient() *http.Client {
 
 	return &http.Client{
 		Timeout:   time.Second * 300,
-		Transport: transport,
+		Transport: Chain(transport, middlewares...),
 	}
 }
 
@@ -76,20 +143,45 @@ func MakeHTTPRequest(ctx context.Context, req HttpRequest) (*http.Response, erro
 		httpClient.Timeout = 30 * time.Second
 	}
 
+	if req.BodyObject != nil && req.BodyProvider == nil && req.BodyReader == nil {
+		if req.Headers == nil {
+			req.Headers = make(map[string]string)
+		}
+		if _, ok := req.Headers["Content-Type"]; !ok {
+			req.Headers["Content-Type"] = req.requestCodec().ContentType()
+		}
+	}
+
+	if _, ok := RequestIDFromContext(ctx); !ok {
+		ctx = WithRequestID(ctx, uuid.NewString())
+	}
+
 	buf := bufferPool.Get().(*bytes.Buffer)
 	defer bufferPool.Put(buf)
-	buf.Reset()
-	buf.ReadFrom(bytes.NewReader(req.Body))
+
+	// attempt tracks retry attempts only (the first send counts as attempt
+	// 1); redirects and 401 token renewals reuse the same attempt and don't
+	// consume the retry budget, since RedirectPolicy enforces its own cap
+	// via redirectChain.
+	attempt := 1
+	if counter, ok := ctx.Value(attemptCountKey{}).(*int); ok {
+		*counter = attempt
+	}
+	var redirectChain []string
 
 	for {
-		httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.Url, buf)
+		body, err := req.openBody(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.Url, body)
 		if err != nil {
 			return nil, err
 		}
 
 		for key, value := range req.Headers {
 			httpReq.Header.Add(key, value)
-			// TODO: Add request-id for every outgoing requests
 		}
 
 		if req.Query != nil {
@@ -109,20 +201,54 @@ func MakeHTTPRequest(ctx context.Context, req HttpRequest) (*http.Response, erro
 		}
 
 		logger.Log.Debug(fmt.Sprintf("making HTTP request to %s headers: %v", req.Url, req.Headers))
-		logger.Log.Debug(fmt.Sprintf("request body to %s is %s", req.Url, buf))
+		if req.BodyReader == nil && req.BodyProvider == nil {
+			logger.Log.Debug(fmt.Sprintf("request body to %s is %s", req.Url, buf))
+		}
 
 		res, err := httpClient.Do(httpReq)
 		if err != nil {
+			if req.Retry.allows(req.Method, attempt) && isRetryableError(err) {
+				delay := req.Retry.backoff(attempt)
+				logger.Log.Warn("transient error making HTTP request, retrying",
+					zap.String("url", req.Url), zap.Int("attempt", attempt), zap.Duration("delay", delay), zap.Error(err))
+				if !sleepForRetry(ctx, delay) {
+					return nil, ctx.Err()
+				}
+				attempt++
+				if counter, ok := ctx.Value(attemptCountKey{}).(*int); ok {
+					*counter = attempt
+				}
+				continue
+			}
 			return nil, err
 		}
 
-		if res.StatusCode == http.StatusTemporaryRedirect {
-			location := res.Header.Get("Location")
-			if location == "" {
-				return res, errors.New("no Location header found in 307 response")
+		if isRedirectStatus(res.StatusCode) {
+			nextURL, nextMethod, preserveBody, crossHost, ok := resolveRedirect(req.Redirect, httpReq, res)
+			if ok {
+				io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+
+				if len(redirectChain) >= req.Redirect.maxRedirects() {
+					return nil, &RedirectLoopError{Chain: append(redirectChain, req.Url, nextURL)}
+				}
+				redirectChain = append(redirectChain, req.Url)
+
+				if crossHost && req.Redirect.stripAuthOnCrossHost() {
+					delete(req.Headers, "Authorization")
+				}
+
+				req.Url = nextURL
+				req.Method = nextMethod
+				if !preserveBody {
+					req.Body = nil
+					req.BodyReader = nil
+					req.BodyProvider = nil
+					req.BodyObject = nil
+				}
+				continue
 			}
-			req.Url = location
-			continue
+			logger.Log.Debug(fmt.Sprintf("got %d redirect from %s, not following it", res.StatusCode, req.Url))
 		}
 
 		if res.StatusCode == http.StatusUnauthorized && req.onRenewBearer != nil {
@@ -132,8 +258,25 @@ func MakeHTTPRequest(ctx context.Context, req HttpRequest) (*http.Response, erro
 				return nil, err
 			}
 			req.Headers["Authorization"] = "Bearer " + token
-			buf.Reset()
-			buf.ReadFrom(bytes.NewReader(req.Body))
+			continue
+		}
+
+		if req.Retry.allows(req.Method, attempt) && req.Retry.retryableStatus(res.StatusCode) {
+			delay, hasRetryAfter := parseRetryAfter(res.Header)
+			if !hasRetryAfter {
+				delay = req.Retry.backoff(attempt)
+			}
+			logger.Log.Warn("got retryable status making HTTP request, retrying",
+				zap.String("url", req.Url), zap.Int("status", res.StatusCode), zap.Int("attempt", attempt), zap.Duration("delay", delay))
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			if !sleepForRetry(ctx, delay) {
+				return nil, ctx.Err()
+			}
+			attempt++
+			if counter, ok := ctx.Value(attemptCountKey{}).(*int); ok {
+				*counter = attempt
+			}
 			continue
 		}
 
@@ -173,9 +316,34 @@ func RequestAutoBodyParser(ctx context.Context, req HttpRequest, result interfac
 	}
 
 	// Accept 1xx, 2xx, 3xx to be parsed and will be handled by the caller
-	if err := sonic.Unmarshal(body, result); err != nil {
+	codec := codecFor(resp.Header.Get("Content-Type"))
+	if err := codec.Unmarshal(body, result); err != nil {
 		return resp, body, fmt.Errorf("(Unxpected 4xx) got %d response from %s is %s", resp.StatusCode, req.Url, body)
 	}
 
 	return resp, body, nil
 }
+
+// RequestStream makes an HTTP request like RequestAutoBodyParser but, instead
+// of buffering the whole response, hands the caller the raw body so large
+// downloads (e.g. registry-blob-sized payloads) don't have to fit in memory.
+// The caller must close the returned io.ReadCloser. As with the buffered
+// variant, a 5xx response is treated as an error; any other status (1xx,
+// 2xx, 3xx, 4xx) is returned unread for the caller to stream and handle
+// itself.
+func RequestStream(ctx context.Context, req HttpRequest) (*http.Response, io.ReadCloser, error) {
+	resp, err := MakeHTTPRequest(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger.Log.Debug(fmt.Sprintf("streaming response from %s", req.Url), zap.Int("status_code", resp.StatusCode), zap.Int64("content_length", resp.ContentLength))
+
+	if resp.StatusCode >= 500 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 10000))
+		return resp, nil, fmt.Errorf("(Unxpected 5xx) got %d response from %s is %s", resp.StatusCode, req.Url, body)
+	}
+
+	return resp, resp.Body, nil
+}
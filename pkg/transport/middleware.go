@@ -0,0 +1,18 @@
+package transport
+
+import "net/http"
+
+// Middleware wraps an http.RoundTripper with additional behavior (logging,
+// metrics, retry, auth, ...). Middlewares are applied outermost-first: the
+// first middleware passed to NewHTTPClient is the first one a request
+// passes through, and the last sits closest to the network.
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// Chain wraps base with middlewares, applying them outermost-first.
+func Chain(base http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	rt := base
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		rt = middlewares[i](rt)
+	}
+	return rt
+}
@@ -0,0 +1,72 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+)
+
+// FileUpload describes a single file part for NewMultipartRequest.
+type FileUpload struct {
+	// FileName is the filename reported in the part's Content-Disposition.
+	FileName string
+	// Content is the file's data. It's read once per attempt the request
+	// is sent, same as any other BodyProvider stream; pass a Content that
+	// can't be re-read if the request will never be retried/redirected.
+	Content io.Reader
+}
+
+// NewMultipartRequest builds an HttpRequest whose body is streamed as
+// multipart/form-data from a set of plain fields and file uploads via
+// BodyProvider, so large file uploads never need to be buffered in
+// memory. Headers["Content-Type"] is set to the matching boundary
+// content-type and Method defaults to POST. Callers still need to set Url
+// and anything else HttpRequest needs.
+func NewMultipartRequest(fields map[string]string, files map[string]FileUpload) (HttpRequest, error) {
+	boundaryWriter := multipart.NewWriter(io.Discard)
+	boundary := boundaryWriter.Boundary()
+	contentType := boundaryWriter.FormDataContentType()
+
+	return HttpRequest{
+		Method: "POST",
+		BodyProvider: func() (io.ReadCloser, error) {
+			pr, pw := io.Pipe()
+
+			writer := multipart.NewWriter(pw)
+			if err := writer.SetBoundary(boundary); err != nil {
+				return nil, fmt.Errorf("transport: setting multipart boundary: %w", err)
+			}
+
+			go func() {
+				pw.CloseWithError(writeMultipartBody(writer, fields, files))
+			}()
+
+			return pr, nil
+		},
+		Headers: map[string]string{
+			"Content-Type": contentType,
+		},
+	}, nil
+}
+
+// writeMultipartBody writes fields and files into writer, streaming each
+// file straight from its Content reader instead of buffering it.
+func writeMultipartBody(writer *multipart.Writer, fields map[string]string, files map[string]FileUpload) error {
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return fmt.Errorf("transport: writing multipart field %q: %w", name, err)
+		}
+	}
+
+	for name, file := range files {
+		part, err := writer.CreateFormFile(name, file.FileName)
+		if err != nil {
+			return fmt.Errorf("transport: creating multipart file %q: %w", name, err)
+		}
+		if _, err := io.Copy(part, file.Content); err != nil {
+			return fmt.Errorf("transport: writing multipart file %q: %w", name, err)
+		}
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// HostRateLimit is the rate limit applied to one downstream host by
+// WithHostRateLimit.
+type HostRateLimit struct {
+	Host  string
+	RPS   float64
+	Burst int
+}
+
+type hostRateLimitTransport struct {
+	next    http.RoundTripper
+	configs map[string]HostRateLimit
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+func (t *hostRateLimitTransport) base() http.RoundTripper {
+	if t.next != nil {
+		return t.next
+	}
+	return http.DefaultTransport
+}
+
+// limiterFor returns the rate.Limiter for host, lazily creating it from
+// the configured HostRateLimit the first time host is seen. ok is false
+// when host has no configured limit, meaning it isn't rate limited at
+// all.
+func (t *hostRateLimitTransport) limiterFor(host string) (*rate.Limiter, bool) {
+	host = strings.ToLower(host)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if limiter, ok := t.limiters[host]; ok {
+		return limiter, true
+	}
+
+	cfg, ok := t.configs[host]
+	if !ok {
+		return nil, false
+	}
+
+	limiter := rate.NewLimiter(rate.Limit(cfg.RPS), cfg.Burst)
+	t.limiters[host] = limiter
+	return limiter, true
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *hostRateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if limiter, ok := t.limiterFor(req.URL.Hostname()); ok {
+		if err := limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.base().RoundTrip(req)
+}
+
+// WithHostRateLimit returns a Middleware that rate-limits requests keyed by
+// target host: each HostRateLimit gets its own *rate.Limiter, all held by
+// the single middleware instance, the same way WithCircuitBreaker keeps
+// one breaker per host behind a single middleware. Hosts not listed pass
+// through unaffected.
+func WithHostRateLimit(limits ...HostRateLimit) Middleware {
+	configs := make(map[string]HostRateLimit, len(limits))
+	for _, limit := range limits {
+		configs[strings.ToLower(limit.Host)] = limit
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &hostRateLimitTransport{
+			next:     next,
+			configs:  configs,
+			limiters: make(map[string]*rate.Limiter),
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RedirectPolicy configures how MakeHTTPRequest follows 3xx redirect
+// responses. A nil *RedirectPolicy on HttpRequest uses the defaults below.
+type RedirectPolicy struct {
+	// MaxRedirects caps how many redirects are followed before
+	// RedirectLoopError is returned. Defaults to 10 when zero.
+	MaxRedirects int
+
+	// FollowSameHostOnly refuses to follow a Location pointing at a host
+	// other than the original request's.
+	FollowSameHostOnly bool
+
+	// StripAuthorizationOnCrossHost removes the Authorization header
+	// before following a redirect to a different host, so credentials
+	// for the original host aren't leaked to a third party.
+	StripAuthorizationOnCrossHost bool
+}
+
+func (p *RedirectPolicy) maxRedirects() int {
+	if p == nil || p.MaxRedirects <= 0 {
+		return 10
+	}
+	return p.MaxRedirects
+}
+
+func (p *RedirectPolicy) sameHostOnly() bool {
+	return p != nil && p.FollowSameHostOnly
+}
+
+func (p *RedirectPolicy) stripAuthOnCrossHost() bool {
+	return p != nil && p.StripAuthorizationOnCrossHost
+}
+
+// RedirectLoopError is returned when a redirect chain exceeds the
+// configured RedirectPolicy.MaxRedirects.
+type RedirectLoopError struct {
+	Chain []string
+}
+
+func (e *RedirectLoopError) Error() string {
+	return fmt.Sprintf("transport: exceeded max redirects, chain: %s", strings.Join(e.Chain, " -> "))
+}
+
+func isRedirectStatus(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	default:
+		return false
+	}
+}
+
+// resolveRedirect computes the method, body-preservation policy, and
+// absolute next URL for following res (a 3xx response to httpReq), per
+// policy's method/body rules (RFC 7231 ยง7.1.2): 303 always becomes a GET
+// with no body; 307/308 preserve method and body; 301/302 preserve
+// GET/HEAD and otherwise fall back to a bodyless GET, matching how
+// browsers have handled those codes since before the RFC standardized it.
+// ok is false when the redirect shouldn't be followed at all (no Location
+// header, or the policy forbids crossing hosts).
+func resolveRedirect(policy *RedirectPolicy, httpReq *http.Request, res *http.Response) (nextURL, nextMethod string, preserveBody, crossHost, ok bool) {
+	location := res.Header.Get("Location")
+	if location == "" {
+		return "", "", false, false, false
+	}
+
+	resolved, err := httpReq.URL.Parse(location)
+	if err != nil {
+		return "", "", false, false, false
+	}
+
+	crossHost = !strings.EqualFold(resolved.Hostname(), httpReq.URL.Hostname())
+	if crossHost && policy.sameHostOnly() {
+		return "", "", false, false, false
+	}
+
+	method := httpReq.Method
+	preserve := false
+
+	switch res.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound:
+		if method != http.MethodGet && method != http.MethodHead {
+			method = http.MethodGet
+		}
+	case http.StatusSeeOther:
+		method = http.MethodGet
+	case http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		preserve = true
+	default:
+		return "", "", false, false, false
+	}
+
+	return resolved.String(), method, preserve, crossHost, true
+}
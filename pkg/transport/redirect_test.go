@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"net/http"
+	"testing"
+)
+
+func newRedirectReq(t *testing.T, method, url string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest(%q, %q) error: %v", method, url, err)
+	}
+	return req
+}
+
+func newRedirectRes(status int, location string) *http.Response {
+	header := http.Header{}
+	if location != "" {
+		header.Set("Location", location)
+	}
+	return &http.Response{StatusCode: status, Header: header}
+}
+
+func TestResolveRedirectMethodAndBodyMatrix(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		requestMethod string
+		wantMethod    string
+		wantPreserve  bool
+	}{
+		{name: "301 preserves GET", status: http.StatusMovedPermanently, requestMethod: http.MethodGet, wantMethod: http.MethodGet, wantPreserve: false},
+		{name: "301 downgrades POST to GET", status: http.StatusMovedPermanently, requestMethod: http.MethodPost, wantMethod: http.MethodGet, wantPreserve: false},
+		{name: "302 preserves HEAD", status: http.StatusFound, requestMethod: http.MethodHead, wantMethod: http.MethodHead, wantPreserve: false},
+		{name: "302 downgrades PUT to GET", status: http.StatusFound, requestMethod: http.MethodPut, wantMethod: http.MethodGet, wantPreserve: false},
+		{name: "303 always becomes GET", status: http.StatusSeeOther, requestMethod: http.MethodPost, wantMethod: http.MethodGet, wantPreserve: false},
+		{name: "307 preserves POST and body", status: http.StatusTemporaryRedirect, requestMethod: http.MethodPost, wantMethod: http.MethodPost, wantPreserve: true},
+		{name: "308 preserves POST and body", status: http.StatusPermanentRedirect, requestMethod: http.MethodPost, wantMethod: http.MethodPost, wantPreserve: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq := newRedirectReq(t, tt.requestMethod, "https://example.com/original")
+			res := newRedirectRes(tt.status, "https://example.com/next")
+
+			nextURL, nextMethod, preserveBody, crossHost, ok := resolveRedirect(nil, httpReq, res)
+			if !ok {
+				t.Fatalf("resolveRedirect() ok = false, want true")
+			}
+			if nextMethod != tt.wantMethod {
+				t.Errorf("method = %q, want %q", nextMethod, tt.wantMethod)
+			}
+			if preserveBody != tt.wantPreserve {
+				t.Errorf("preserveBody = %v, want %v", preserveBody, tt.wantPreserve)
+			}
+			if nextURL != "https://example.com/next" {
+				t.Errorf("nextURL = %q, want %q", nextURL, "https://example.com/next")
+			}
+			if crossHost {
+				t.Errorf("crossHost = true, want false for a same-host redirect")
+			}
+		})
+	}
+}
+
+func TestResolveRedirectMissingLocation(t *testing.T) {
+	httpReq := newRedirectReq(t, http.MethodGet, "https://example.com/original")
+	res := newRedirectRes(http.StatusFound, "")
+
+	if _, _, _, _, ok := resolveRedirect(nil, httpReq, res); ok {
+		t.Errorf("resolveRedirect() ok = true, want false when Location is missing")
+	}
+}
+
+func TestResolveRedirectCrossHost(t *testing.T) {
+	httpReq := newRedirectReq(t, http.MethodGet, "https://example.com/original")
+	res := newRedirectRes(http.StatusFound, "https://attacker.example/next")
+
+	t.Run("detects cross-host", func(t *testing.T) {
+		_, _, _, crossHost, ok := resolveRedirect(nil, httpReq, res)
+		if !ok {
+			t.Fatalf("resolveRedirect() ok = false, want true")
+		}
+		if !crossHost {
+			t.Errorf("crossHost = false, want true")
+		}
+	})
+
+	t.Run("FollowSameHostOnly refuses it", func(t *testing.T) {
+		policy := &RedirectPolicy{FollowSameHostOnly: true}
+		if _, _, _, _, ok := resolveRedirect(policy, httpReq, res); ok {
+			t.Errorf("resolveRedirect() ok = true, want false when FollowSameHostOnly forbids the cross-host hop")
+		}
+	})
+}
+
+func TestRedirectPolicyMaxRedirectsDefault(t *testing.T) {
+	var policy *RedirectPolicy
+	if got := policy.maxRedirects(); got != 10 {
+		t.Errorf("nil policy maxRedirects() = %d, want 10", got)
+	}
+
+	policy = &RedirectPolicy{MaxRedirects: 3}
+	if got := policy.maxRedirects(); got != 3 {
+		t.Errorf("maxRedirects() = %d, want 3", got)
+	}
+}
@@ -0,0 +1,191 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how MakeHTTPRequest retries a request on transient
+// failures. A nil *RetryPolicy (the default) disables retrying entirely,
+// preserving the previous single-attempt behavior.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one.
+	// A value <= 1 disables retrying.
+	MaxAttempts int
+
+	// BaseDelay is the delay used for the first retry, before exponential
+	// growth. Defaults to 200ms when zero.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the computed backoff delay. Defaults to 30s when zero.
+	MaxDelay time.Duration
+
+	// RetryOn lists the HTTP status codes that should trigger a retry.
+	// When empty, it defaults to 429, 502, 503, and 504.
+	RetryOn []int
+
+	// Jitter applies "full jitter" (a random value between 0 and the
+	// computed delay) so concurrent callers don't retry in lockstep.
+	Jitter bool
+
+	// AllowNonIdempotent opts non-idempotent methods (POST, PATCH, ...)
+	// into retrying. By default only GET, HEAD, PUT, DELETE, OPTIONS and
+	// TRACE are retried since resending them can never duplicate effects.
+	AllowNonIdempotent bool
+}
+
+var defaultRetryableStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	statuses := p.RetryOn
+	if len(statuses) == 0 {
+		statuses = defaultRetryableStatus
+	}
+	for _, s := range statuses {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions, http.MethodTrace, "":
+		return true
+	default:
+		return false
+	}
+}
+
+// allows reports whether this policy permits another attempt for the given
+// method. A nil policy never allows retries.
+func (p *RetryPolicy) allows(method string, attempt int) bool {
+	if p == nil || p.MaxAttempts <= 1 {
+		return false
+	}
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if p.AllowNonIdempotent {
+		return true
+	}
+	return isIdempotentMethod(method)
+}
+
+// backoff computes the delay before the given retry attempt (1-indexed),
+// applying exponential growth capped at MaxDelay and, if enabled, full
+// jitter.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = 200 * time.Millisecond
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	delay := base << uint(attempt-1)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+
+	if p.Jitter {
+		delay = time.Duration(rand.Int63n(int64(delay) + 1))
+	}
+
+	return delay
+}
+
+// parseRetryAfter parses the Retry-After header in either its delay-seconds
+// or HTTP-date form, per RFC 7231 ยง7.1.3. It returns false if the header is
+// absent or malformed.
+func parseRetryAfter(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if when, err := http.ParseTime(value); err == nil {
+		delay := time.Until(when)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// isRetryableError reports whether err from httpClient.Do represents a
+// transient network failure worth retrying, as opposed to e.g. the caller's
+// context being canceled.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// sleepForRetry blocks for delay or until ctx is done, whichever comes
+// first. It returns false if ctx was canceled before delay elapsed.
+func sleepForRetry(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+type attemptCountKey struct{}
+
+// WithAttemptCount returns a copy of ctx carrying a counter that
+// MakeHTTPRequest increments on every attempt (including the first). Pass
+// the returned ctx through and read AttemptCount(ctx) after the call
+// returns to see how many attempts were made.
+func WithAttemptCount(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attemptCountKey{}, new(int))
+}
+
+// AttemptCount returns the attempt counter previously attached to ctx via
+// WithAttemptCount, or 0 if none is present.
+func AttemptCount(ctx context.Context) int {
+	if count, ok := ctx.Value(attemptCountKey{}).(*int); ok {
+		return *count
+	}
+	return 0
+}
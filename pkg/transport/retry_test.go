@@ -0,0 +1,139 @@
+package transport
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyAllows(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  *RetryPolicy
+		method  string
+		attempt int
+		want    bool
+	}{
+		{name: "nil policy never retries", policy: nil, method: http.MethodGet, attempt: 1, want: false},
+		{name: "MaxAttempts of 1 disables retrying", policy: &RetryPolicy{MaxAttempts: 1}, method: http.MethodGet, attempt: 1, want: false},
+		{name: "idempotent method within budget", policy: &RetryPolicy{MaxAttempts: 3}, method: http.MethodGet, attempt: 1, want: true},
+		{name: "attempt already exhausted budget", policy: &RetryPolicy{MaxAttempts: 3}, method: http.MethodGet, attempt: 3, want: false},
+		{name: "non-idempotent method refused by default", policy: &RetryPolicy{MaxAttempts: 3}, method: http.MethodPost, attempt: 1, want: false},
+		{name: "non-idempotent method allowed opt-in", policy: &RetryPolicy{MaxAttempts: 3, AllowNonIdempotent: true}, method: http.MethodPost, attempt: 1, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.allows(tt.method, tt.attempt); got != tt.want {
+				t.Errorf("allows(%q, %d) = %v, want %v", tt.method, tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Run("grows exponentially without jitter", func(t *testing.T) {
+		policy := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second}
+
+		if got := policy.backoff(1); got != 100*time.Millisecond {
+			t.Errorf("backoff(1) = %v, want %v", got, 100*time.Millisecond)
+		}
+		if got := policy.backoff(2); got != 200*time.Millisecond {
+			t.Errorf("backoff(2) = %v, want %v", got, 200*time.Millisecond)
+		}
+		if got := policy.backoff(3); got != 400*time.Millisecond {
+			t.Errorf("backoff(3) = %v, want %v", got, 400*time.Millisecond)
+		}
+	})
+
+	t.Run("caps at MaxDelay", func(t *testing.T) {
+		policy := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+		if got := policy.backoff(10); got != 300*time.Millisecond {
+			t.Errorf("backoff(10) = %v, want capped at %v", got, 300*time.Millisecond)
+		}
+	})
+
+	t.Run("uses defaults when unset", func(t *testing.T) {
+		policy := &RetryPolicy{}
+
+		got := policy.backoff(1)
+		if got != 200*time.Millisecond {
+			t.Errorf("backoff(1) with defaults = %v, want %v", got, 200*time.Millisecond)
+		}
+	})
+
+	t.Run("jitter stays within [0, computed delay]", func(t *testing.T) {
+		policy := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 10 * time.Second, Jitter: true}
+
+		for i := 0; i < 50; i++ {
+			got := policy.backoff(3)
+			if got < 0 || got > 400*time.Millisecond {
+				t.Fatalf("backoff(3) with jitter = %v, want within [0, %v]", got, 400*time.Millisecond)
+			}
+		}
+	})
+}
+
+func TestRetryPolicyRetryableStatus(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		policy := &RetryPolicy{}
+		for _, code := range []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+			if !policy.retryableStatus(code) {
+				t.Errorf("retryableStatus(%d) = false, want true", code)
+			}
+		}
+		if policy.retryableStatus(http.StatusInternalServerError) {
+			t.Errorf("retryableStatus(%d) = true, want false", http.StatusInternalServerError)
+		}
+	})
+
+	t.Run("custom RetryOn overrides defaults", func(t *testing.T) {
+		policy := &RetryPolicy{RetryOn: []int{http.StatusInternalServerError}}
+		if !policy.retryableStatus(http.StatusInternalServerError) {
+			t.Errorf("retryableStatus(500) = false, want true")
+		}
+		if policy.retryableStatus(http.StatusBadGateway) {
+			t.Errorf("retryableStatus(502) = true, want false (not in custom RetryOn)")
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Run("absent header", func(t *testing.T) {
+		header := http.Header{}
+		if _, ok := parseRetryAfter(header); ok {
+			t.Errorf("parseRetryAfter() ok = true, want false")
+		}
+	})
+
+	t.Run("delay-seconds form", func(t *testing.T) {
+		header := http.Header{"Retry-After": []string{"120"}}
+		got, ok := parseRetryAfter(header)
+		if !ok {
+			t.Fatalf("parseRetryAfter() ok = false, want true")
+		}
+		if got != 120*time.Second {
+			t.Errorf("parseRetryAfter() = %v, want %v", got, 120*time.Second)
+		}
+	})
+
+	t.Run("HTTP-date form", func(t *testing.T) {
+		when := time.Now().Add(30 * time.Second).UTC()
+		header := http.Header{"Retry-After": []string{when.Format(http.TimeFormat)}}
+		got, ok := parseRetryAfter(header)
+		if !ok {
+			t.Fatalf("parseRetryAfter() ok = false, want true")
+		}
+		if got < 28*time.Second || got > 30*time.Second {
+			t.Errorf("parseRetryAfter() = %v, want close to 30s", got)
+		}
+	})
+
+	t.Run("malformed value", func(t *testing.T) {
+		header := http.Header{"Retry-After": []string{"not-a-delay"}}
+		if _, ok := parseRetryAfter(header); ok {
+			t.Errorf("parseRetryAfter() ok = true, want false")
+		}
+	})
+}
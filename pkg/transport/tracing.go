@@ -0,0 +1,155 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying requestID. MakeHTTPRequest
+// calls this once per logical call (generating a UUIDv4 if ctx doesn't
+// already carry one) so every attempt in its retry/redirect/401-renewal
+// loop reuses the same id instead of TracingMiddleware minting a new one
+// per RoundTrip.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request id previously attached via
+// WithRequestID, and whether one was present.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+const instrumentationName = "github.com/kondohiroki/go-grpc-boilerplate/pkg/transport"
+
+// DefaultRequestIDHeader is the header TracingMiddleware sets when the
+// outgoing request doesn't already carry one.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// TracingConfig configures TracingMiddleware.
+type TracingConfig struct {
+	// RequestIDHeader overrides the header used to propagate a request id.
+	// Defaults to DefaultRequestIDHeader.
+	RequestIDHeader string
+
+	// TracerProvider and MeterProvider override the global providers.
+	// Nil uses otel.GetTracerProvider() / otel.GetMeterProvider().
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+}
+
+type tracingTransport struct {
+	next            http.RoundTripper
+	tracer          trace.Tracer
+	requests        metric.Int64Counter
+	duration        metric.Float64Histogram
+	requestIDHeader string
+}
+
+// TracingMiddleware returns a Middleware that starts a span per HTTP
+// attempt, so redirects, 401 renewals, and retries performed by
+// MakeHTTPRequest each get their own child span linked to the caller's
+// parent span. It also injects a W3C traceparent header plus a
+// request-id header (generated as a UUIDv4 when the caller didn't set
+// one), and records http_client_requests_total /
+// http_client_request_duration_seconds, labeled by host, method, and
+// status class.
+func TracingMiddleware(cfg TracingConfig) Middleware {
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = DefaultRequestIDHeader
+	}
+
+	tracerProvider := cfg.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	meterProvider := cfg.MeterProvider
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+
+	tracer := tracerProvider.Tracer(instrumentationName)
+	meter := meterProvider.Meter(instrumentationName)
+
+	requests, _ := meter.Int64Counter("http_client_requests_total",
+		metric.WithDescription("Total outgoing HTTP client requests."))
+	duration, _ := meter.Float64Histogram("http_client_request_duration_seconds",
+		metric.WithDescription("Outgoing HTTP client request duration in seconds."))
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &tracingTransport{
+			next:            next,
+			tracer:          tracer,
+			requests:        requests,
+			duration:        duration,
+			requestIDHeader: cfg.RequestIDHeader,
+		}
+	}
+}
+
+func (t *tracingTransport) base() http.RoundTripper {
+	if t.next != nil {
+		return t.next
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(req.Context(), fmt.Sprintf("HTTP %s", req.Method), trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	req = req.Clone(ctx)
+	if req.Header.Get(t.requestIDHeader) == "" {
+		requestID, ok := RequestIDFromContext(ctx)
+		if !ok {
+			// Only reachable when RoundTrip is driven directly rather than
+			// through MakeHTTPRequest, which always seeds one.
+			requestID = uuid.NewString()
+		}
+		req.Header.Set(t.requestIDHeader, requestID)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	span.SetAttributes(semconv.HTTPMethod(req.Method), semconv.HTTPURL(req.URL.String()))
+
+	start := time.Now()
+	res, err := t.base().RoundTrip(req)
+	elapsed := time.Since(start).Seconds()
+
+	statusClass := "error"
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		statusClass = fmt.Sprintf("%dxx", res.StatusCode/100)
+		span.SetAttributes(semconv.HTTPStatusCode(res.StatusCode), attribute.Int64("http.response_content_length", res.ContentLength))
+		if res.StatusCode >= 500 {
+			span.SetStatus(codes.Error, res.Status)
+		}
+	}
+
+	attrs := metric.WithAttributes(
+		attribute.String("host", req.URL.Hostname()),
+		attribute.String("method", req.Method),
+		attribute.String("status_class", statusClass),
+	)
+	t.requests.Add(ctx, 1, attrs)
+	t.duration.Record(ctx, elapsed, attrs)
+
+	return res, err
+}